@@ -0,0 +1,222 @@
+/*
+Copyright 2019 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/bootstrapper"
+	"k8s.io/minikube/pkg/minikube/cluster"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/logs"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+const defaultNumberOfLines = 60
+
+var (
+	follow        bool
+	numberOfLines int
+	showProblems  bool
+	explainFormat bool
+	bundleFile    string
+	logsSince     string
+	logsUntil     string
+	logsGrep      string
+	logsSeverity  string
+	recordDaemon  bool
+	recordDir     string
+	showPrevious  bool
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Returns logs to debug a local Kubernetes cluster",
+	Long:  "Gets the logs of the running instance, used for debugging minikube, not user code.",
+	Run: func(cmd *cobra.Command, args []string) {
+		r, bs, cc, runner := logsContext(ClusterFlagValue())
+		filter := buildLogFilter()
+
+		if recordDaemon {
+			runLogRecorderDaemon(r, bs, cc, runner, recordDir)
+			return
+		}
+
+		if bundleFile != "" {
+			outputBundle(r, bs, cc, runner)
+			return
+		}
+
+		if showPrevious {
+			if err := logs.OutputPrevious(cc, numberOfLines, os.Stdout, filter); err != nil {
+				exit.Error(reason.InternalCommandRunner, "fetching previous container logs failed", err)
+			}
+			return
+		}
+
+		if follow {
+			if err := logs.Follow(r, bs, cc, runner, os.Stdout, filter); err != nil {
+				exit.Error(reason.InternalCommandRunner, "following logs failed", err)
+			}
+			return
+		}
+
+		if showProblems {
+			problems := logs.FindProblems(r, bs, cc, runner, filter)
+			if explainFormat {
+				logs.OutputProblemsExplain(problems, numberOfLines, os.Stdout)
+			} else {
+				logs.OutputProblems(problems, numberOfLines, os.Stdout)
+			}
+			return
+		}
+
+		logs.Output(r, bs, cc, runner, numberOfLines, os.Stdout, filter)
+	},
+}
+
+// buildLogFilter turns --since/--until/--grep/--severity into a *logs.LogFilter, exiting with a
+// user-facing error if any of them fail to parse. Returns nil if none were set, so callers can
+// pass it straight through to functions that already treat a nil filter as "no filtering".
+func buildLogFilter() *logs.LogFilter {
+	if logsSince == "" && logsUntil == "" && logsGrep == "" && logsSeverity == "" {
+		return nil
+	}
+
+	f := &logs.LogFilter{}
+
+	if logsSince != "" {
+		d, err := time.ParseDuration(logsSince)
+		if err != nil {
+			exit.Error(reason.Usage, "invalid --since duration", err)
+		}
+		f.Since = d
+	}
+
+	if logsUntil != "" {
+		t, err := time.Parse(time.RFC3339, logsUntil)
+		if err != nil {
+			exit.Error(reason.Usage, "invalid --until timestamp, expected RFC3339 (e.g. 2021-01-02T15:04:05Z)", err)
+		}
+		f.Until = t
+	}
+
+	if logsGrep != "" {
+		re, err := regexp.Compile(logsGrep)
+		if err != nil {
+			exit.Error(reason.Usage, "invalid --grep pattern", err)
+		}
+		f.Grep = re
+	}
+
+	if logsSeverity != "" {
+		sev := logs.Severity(logsSeverity)
+		switch sev {
+		case logs.SeverityInfo, logs.SeverityWarn, logs.SeverityError:
+			f.MinSeverity = sev
+		default:
+			exit.Error(reason.Usage, "invalid --severity, must be one of info, warn, error", nil)
+		}
+	}
+
+	return f
+}
+
+// runLogRecorderDaemon runs a logs.Recorder in the foreground until it receives SIGINT/SIGTERM,
+// flushing its manifest on the way out. This is the detached child process
+// startDetachedLogRecorder (start.go) spawns for `minikube start --record-logs`, and the one
+// stopLogRecorder (stop.go) signals on `minikube stop`; it isn't meant to be run directly.
+func runLogRecorderDaemon(r cruntime.Manager, bs bootstrapper.Bootstrapper, cc config.ClusterConfig, runner command.Runner, dir string) {
+	rec := logs.NewRecorder(r, bs, cc, runner, dir)
+	if err := rec.Start(); err != nil {
+		klog.Fatalf("log recorder: failed to start: %v", err)
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+
+	rec.Stop()
+}
+
+// outputBundle writes a support bundle for the active cluster to bundleFile.
+func outputBundle(r cruntime.Manager, bs bootstrapper.Bootstrapper, cc config.ClusterConfig, runner command.Runner) {
+	f, err := os.Create(bundleFile)
+	if err != nil {
+		exit.Error(reason.HostHomeMkdir, "failed to create bundle file", err)
+	}
+	defer f.Close()
+
+	if err := logs.Bundle(r, bs, cc, runner, f); err != nil {
+		exit.Error(reason.InternalCommandRunner, "failed to write bundle", err)
+	}
+	out.Step(style.Success, "Wrote diagnostic bundle to {{.path}}", out.V{"path": bundleFile})
+}
+
+// logsContext resolves the running cluster's runtime, bootstrapper, config and command runner,
+// the same dependencies every pkg/minikube/logs entry point needs.
+func logsContext(profile string) (cruntime.Manager, bootstrapper.Bootstrapper, config.ClusterConfig, command.Runner) {
+	co := mustload.Running(profile)
+	cc := *co.Config
+
+	r, err := cruntime.New(cruntime.Config{Type: cc.KubernetesConfig.ContainerRuntime, Runner: co.CP.Runner})
+	if err != nil {
+		exit.Error(reason.InternalNewRuntime, "failed to get runtime", err)
+	}
+
+	bs, err := cluster.Bootstrapper(co.API, viper.GetString("bootstrapper"), cc, co.CP.Runner)
+	if err != nil {
+		exit.Error(reason.InternalBootstrapper, "failed to get bootstrapper", err)
+	}
+
+	return r, bs, cc, co.CP.Runner
+}
+
+func init() {
+	logsCmd.Flags().IntVarP(&numberOfLines, "length", "n", defaultNumberOfLines, "Number of lines back to go within the log")
+	logsCmd.Flags().BoolVar(&follow, "follow", false, "Show only the most recent journal entries, and continuously print new entries as they are appended to the journal.")
+	logsCmd.Flags().BoolVar(&showProblems, "problems", false, "Show only log entries which point to known problems")
+	logsCmd.Flags().BoolVar(&explainFormat, "explain", false, "With --problems, also print each matched rule's name, severity, component and doc link alongside the log line and suggested fix")
+	logsCmd.Flags().StringVar(&bundleFile, "bundle", "", "Write a tar.gz diagnostic bundle containing every log source to this path, instead of printing logs")
+	logsCmd.Flags().BoolVar(&showPrevious, "previous", false, "Show the last crashed container's logs for each recorded pod, instead of its current logs")
+	logsCmd.Flags().StringVar(&logsSince, "since", "", "Only show lines newer than this relative duration (e.g. 10m, 1.5h)")
+	logsCmd.Flags().StringVar(&logsUntil, "until", "", "Only show lines older than this RFC3339 timestamp (e.g. 2021-01-02T15:04:05Z)")
+	logsCmd.Flags().StringVar(&logsGrep, "grep", "", "Only show lines matching this regular expression")
+	logsCmd.Flags().StringVar(&logsSeverity, "severity", "", "Only show lines that match a known problem of at least this severity: info, warn, error")
+	logsCmd.Flags().BoolVar(&recordDaemon, "record-daemon", false, "Run as the detached background process that records logs for 'minikube start --record-logs' (do not use directly)")
+	logsCmd.Flags().StringVar(&recordDir, "record-dir", "", "Directory to record logs to, used together with --record-daemon")
+	if err := logsCmd.Flags().MarkHidden("record-daemon"); err != nil {
+		klog.Warningf("failed to hide --record-daemon: %v", err)
+	}
+	if err := logsCmd.Flags().MarkHidden("record-dir"); err != nil {
+		klog.Warningf("failed to hide --record-dir: %v", err)
+	}
+	RootCmd.AddCommand(logsCmd)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2019 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/out"
+)
+
+var recordLogsDir string
+
+// startCmd is a minimal skeleton hosting only the --record-logs lifecycle call site; the actual
+// cluster bring-up (driver selection, kubeadm init, addons, ...) lives in the real start.go,
+// which isn't part of this tree.
+var startCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Starts a local Kubernetes cluster",
+	Run: func(cmd *cobra.Command, args []string) {
+		profile := ClusterFlagValue()
+
+		// ... cluster bring-up happens here in the real start.go ...
+
+		if recordLogsDir != "" {
+			if err := startDetachedLogRecorder(profile, recordLogsDir); err != nil {
+				out.WarningT("Failed to start log recorder: {{.error}}", out.V{"error": err})
+			}
+		}
+	},
+}
+
+func init() {
+	startCmd.Flags().StringVar(&recordLogsDir, "record-logs", "", "Continuously record per-container and runtime system logs to this directory for the life of the cluster, so crash output survives long enough for 'minikube logs' to find it later")
+	RootCmd.AddCommand(startCmd)
+}
+
+// startDetachedLogRecorder spawns `minikube logs --record-daemon` as a background process that
+// outlives this start invocation - recording needs to continue for the life of the cluster, but
+// start returns as soon as bring-up finishes. recorderPidFile records its pid so a later
+// `minikube stop`, a separate process, can find and signal it; see stopLogRecorder in stop.go.
+func startDetachedLogRecorder(profile, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	c := exec.Command(os.Args[0], "logs", "--record-daemon", "--record-dir", dir, "-p", profile)
+	if err := c.Start(); err != nil {
+		return err
+	}
+	klog.Infof("log recorder: started detached recorder pid %d for %s -> %s", c.Process.Pid, profile, dir)
+	return os.WriteFile(recorderPidFile(profile), []byte(strconv.Itoa(c.Process.Pid)), 0o644)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2019 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+// stopCmd is a minimal skeleton hosting only the --record-logs teardown call site; the actual
+// cluster stop sequence (halting the host, releasing the driver, ...) lives in the real stop.go,
+// which isn't part of this tree.
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stops a running local Kubernetes cluster",
+	Run: func(cmd *cobra.Command, args []string) {
+		stopLogRecorder(ClusterFlagValue())
+
+		// ... cluster teardown happens here in the real stop.go ...
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(stopCmd)
+}
+
+// stopLogRecorder signals the detached --record-logs process for profile, started by
+// startDetachedLogRecorder in start.go, to flush its manifest and exit. It's a no-op if
+// --record-logs was never used for this profile.
+func stopLogRecorder(profile string) {
+	pidPath := recorderPidFile(profile)
+	b, err := os.ReadFile(pidPath)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		klog.Warningf("log recorder: failed to read %s: %v", pidPath, err)
+		return
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		klog.Warningf("log recorder: invalid pid in %s: %v", pidPath, err)
+		return
+	}
+
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return
+	}
+	if err := proc.Signal(syscall.SIGTERM); err != nil {
+		klog.Warningf("log recorder: failed to signal pid %d: %v", pid, err)
+	}
+	os.Remove(pidPath)
+}
+
+// recorderPidFile is where startDetachedLogRecorder records the detached recorder's pid, so a
+// later `minikube stop` - a different process - can find and signal it.
+func recorderPidFile(profile string) string {
+	return filepath.Join(localpath.Profile(profile), "log-recorder.pid")
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2019 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/kapi"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+)
+
+// apiPodLogs fetches logs for every pod whose name contains one of wantPods directly through
+// the apiserver's Pods().GetLogs(), rather than composing a crictl/docker shell pipeline through
+// the SSH runner. This is correct on drivers (e.g. Hyper-V) where "/bin/bash -c cmd1 & cmd2 &
+// wait" behaves poorly, and it's the only way to honor previous=true, since ContainerLogCmd has
+// no equivalent for a crashed container's last output.
+//
+// ok is false when the apiserver could not be reached, so callers fall back to the runtime
+// command path - the same condition FindProblems already has to tolerate.
+func apiPodLogs(cfg config.ClusterConfig, wantPods []string, length int, follow, previous bool, filter *LogFilter) (sources map[string]io.ReadCloser, ok bool) {
+	cs, err := kapi.Client(cfg.Name)
+	if err != nil {
+		klog.Infof("apiserver unreachable, falling back to runtime log commands: %v", err)
+		return nil, false
+	}
+
+	ctx := context.Background()
+	pods, err := cs.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Infof("apiserver unreachable, falling back to runtime log commands: %v", err)
+		return nil, false
+	}
+
+	var tailLines *int64
+	if length > 0 {
+		l := int64(length)
+		tailLines = &l
+	}
+	var sinceSeconds *int64
+	if filter != nil && filter.Since > 0 {
+		s := int64(filter.Since.Seconds())
+		sinceSeconds = &s
+	}
+
+	sources = map[string]io.ReadCloser{}
+	for _, pod := range pods.Items {
+		want := matchingPod(pod.Name, wantPods)
+		if want == "" {
+			continue
+		}
+		for _, c := range pod.Spec.Containers {
+			key := fmt.Sprintf("%s [%s]", want, c.Name)
+			opts := &corev1.PodLogOptions{
+				Container:    c.Name,
+				Follow:       follow,
+				Previous:     previous,
+				TailLines:    tailLines,
+				SinceSeconds: sinceSeconds,
+			}
+			rc, err := cs.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).Stream(ctx)
+			if err != nil {
+				klog.Warningf("failed to stream apiserver logs for %s: %v", key, err)
+				continue
+			}
+			sources[key] = filterStream(filter, key, rc)
+		}
+	}
+	return sources, true
+}
+
+// runtimeKind maps a cruntime.Manager's name to the sinceFlag kind for its container log
+// command: "docker" for docker, "crictl" for the crictl-based runtimes (containerd, CRI-O).
+// sinceFlag renders the same --since=<duration> flag for both kinds.
+func runtimeKind(r cruntime.Manager) string {
+	if strings.EqualFold(r.Name(), "docker") {
+		return "docker"
+	}
+	return "crictl"
+}
+
+// matchingPod returns the wantPods entry that pod is an instance of, or "" if none match.
+func matchingPod(pod string, wantPods []string) string {
+	for _, want := range wantPods {
+		if strings.Contains(pod, want) {
+			return want
+		}
+	}
+	return ""
+}
+
+// streamAll copies every source to dst concurrently, prefixing each line with its source name so
+// that interleaved output stays attributable - the in-process replacement for backgrounding
+// "crictl logs -f" shell jobs and letting them race to write the same fd.
+func streamAll(dst io.Writer, sources map[string]io.ReadCloser) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for name, rc := range sources {
+		wg.Add(1)
+		go func(name string, rc io.ReadCloser) {
+			defer wg.Done()
+			defer rc.Close()
+			scanner := bufio.NewScanner(rc)
+			for scanner.Scan() {
+				mu.Lock()
+				fmt.Fprintf(dst, "[%s] %s\n", name, scanner.Text())
+				mu.Unlock()
+			}
+		}(name, rc)
+	}
+	wg.Wait()
+}
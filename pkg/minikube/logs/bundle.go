@@ -0,0 +1,179 @@
+/*
+Copyright 2019 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/audit"
+	"k8s.io/minikube/pkg/minikube/bootstrapper"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+// podLogCommandKeyRe strips the " [<containerID>]" disambiguator podLogCommands appends to a pod
+// name, so bundle tar entries read "etcd.log" rather than "etcd [3f9a1b2c3d4e].log".
+var podLogCommandKeyRe = regexp.MustCompile(`^(.+) \[[0-9a-f]+\]$`)
+
+// bundleFileName turns a logCommands key into the tar entry name for it: the pod name alone when
+// the key is disambiguated by a trailing "[<containerID>]", or the key as-is otherwise (e.g. the
+// non-pod sources like "kubelet", "docker"). seen dedupes collisions that stripping the
+// disambiguator can create, e.g. two etcd containers both becoming "etcd.log".
+func bundleFileName(name string, seen map[string]int) string {
+	if m := podLogCommandKeyRe.FindStringSubmatch(name); m != nil {
+		name = m[1]
+	}
+	seen[name]++
+	if n := seen[name]; n > 1 {
+		return fmt.Sprintf("%s-%d.log", name, n)
+	}
+	return name + ".log"
+}
+
+// bundleLookBackwardsCount mirrors lookBackwardsCount, used when collecting per-source logs for a bundle.
+const bundleLookBackwardsCount = lookBackwardsCount
+
+// Bundle gathers every diagnostic source minikube knows about into a single tar.gz, so that
+// users and maintainers have one artifact to attach to a bug report instead of scraping stdout.
+func Bundle(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, runner command.Runner, out io.Writer) error {
+	gw := gzip.NewWriter(out)
+	defer gw.Close()
+	tw := tar.NewWriter(gw)
+	defer tw.Close()
+
+	cmds := logCommands(r, bs, cfg, bundleLookBackwardsCount, false, nil)
+	seen := map[string]int{}
+	for name, cmdline := range cmds {
+		var b bytes.Buffer
+		c := exec.Command("/bin/bash", "-c", cmdline)
+		c.Stdout = &b
+		c.Stderr = &b
+		if rr, err := runner.RunCmd(c); err != nil {
+			klog.Warningf("bundle: command %s failed: %v output: %s", rr.Command(), err, rr.Output())
+		}
+		if err := addTarFile(tw, bundleFileName(name, seen), b.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	if err := addAuditFiles(tw); err != nil {
+		return err
+	}
+
+	if err := addLastStartLog(tw); err != nil {
+		return err
+	}
+
+	addCommandOutput(tw, "cluster-info-dump.log", exec.Command("kubectl", "cluster-info", "dump"))
+	addRemoteCommandOutput(tw, runner, r.Name()+"-info.log", r.Name()+" info")
+	addRemoteCommandOutput(tw, runner, r.Name()+"-version.log", r.Name()+" version")
+	addCommandOutput(tw, "uname.log", exec.Command("uname", "-a"))
+	addCommandOutput(tw, "os-release.log", exec.Command("cat", "/etc/os-release"))
+
+	problems := FindProblems(r, bs, cfg, runner, nil)
+	pb, err := json.MarshalIndent(problems, "", "  ")
+	if err != nil {
+		return err
+	}
+	return addTarFile(tw, "problems.json", pb)
+}
+
+// addAuditFiles writes the audit report in both human-readable and JSON form.
+func addAuditFiles(tw *tar.Writer) error {
+	rep, err := audit.Report(0)
+	if err != nil {
+		return addTarFile(tw, "audit.log", []byte(err.Error()))
+	}
+	if err := addTarFile(tw, "audit.log", []byte(rep.ASCIITable())); err != nil {
+		return err
+	}
+	jb, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return err
+	}
+	return addTarFile(tw, "audit.json", jb)
+}
+
+// addLastStartLog copies the last-start log into the bundle, if one exists.
+func addLastStartLog(tw *tar.Writer) error {
+	fp := localpath.LastStartLog()
+	b, err := os.ReadFile(fp)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return addTarFile(tw, "last-start.log", b)
+}
+
+// addCommandOutput runs cmd locally and adds its combined output to the bundle, ignoring
+// failures (many of these commands are only meaningful on the host running the CLI).
+func addCommandOutput(tw *tar.Writer, name string, cmd *exec.Cmd) {
+	var b bytes.Buffer
+	cmd.Stdout = &b
+	cmd.Stderr = &b
+	if err := cmd.Run(); err != nil {
+		klog.Infof("bundle: %s failed (non-fatal): %v", name, err)
+	}
+	if err := addTarFile(tw, name, b.Bytes()); err != nil {
+		klog.Warningf("bundle: failed to add %s: %v", name, err)
+	}
+}
+
+// addRemoteCommandOutput runs cmdline through runner - so it executes on the minikube node
+// rather than the host running the CLI - and adds its combined output to the bundle, ignoring
+// failures.
+func addRemoteCommandOutput(tw *tar.Writer, runner command.Runner, name, cmdline string) {
+	var b bytes.Buffer
+	c := exec.Command("/bin/bash", "-c", cmdline)
+	c.Stdout = &b
+	c.Stderr = &b
+	if rr, err := runner.RunCmd(c); err != nil {
+		klog.Infof("bundle: %s failed (non-fatal): %v output: %s", name, err, rr.Output())
+	}
+	if err := addTarFile(tw, name, b.Bytes()); err != nil {
+		klog.Warningf("bundle: failed to add %s: %v", name, err)
+	}
+}
+
+// addTarFile writes a single in-memory file to the tar stream.
+func addTarFile(tw *tar.Writer, name string, content []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(content)
+	return err
+}
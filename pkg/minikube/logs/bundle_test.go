@@ -0,0 +1,35 @@
+/*
+Copyright 2019 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import "testing"
+
+func TestBundleFileName(t *testing.T) {
+	seen := map[string]int{}
+
+	if got := bundleFileName("etcd [3f9a1b2c3d4e]", seen); got != "etcd.log" {
+		t.Errorf("bundleFileName stripped name = %q, want etcd.log", got)
+	}
+	if got := bundleFileName("kubelet", seen); got != "kubelet.log" {
+		t.Errorf("bundleFileName non-pod key = %q, want kubelet.log", got)
+	}
+	// A second container for the same pod, after stripping, collides with "etcd" - it should
+	// get a disambiguating suffix rather than silently overwriting the first entry.
+	if got := bundleFileName("etcd [a1b2c3d4e5f6]", seen); got != "etcd-2.log" {
+		t.Errorf("bundleFileName colliding name = %q, want etcd-2.log", got)
+	}
+}
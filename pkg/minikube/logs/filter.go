@@ -0,0 +1,192 @@
+/*
+Copyright 2019 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// LogFilter narrows the lines Output, Follow and FindProblems return: Since/Until bound the time
+// window, Grep keeps only matching lines, and MinSeverity drops anything that doesn't match a
+// problem rule at least that severe. A nil or zero-value LogFilter passes everything through
+// unchanged. This turns `minikube logs` from a firehose into a triage tool for long-running dev
+// clusters.
+type LogFilter struct {
+	Since       time.Duration
+	Until       time.Time
+	Grep        *regexp.Regexp
+	MinSeverity Severity
+}
+
+// severityRank orders Severity so MinSeverity can be compared.
+var severityRank = map[Severity]int{
+	SeverityInfo:  0,
+	SeverityWarn:  1,
+	SeverityError: 2,
+}
+
+// klogTimestampRe matches the leading timestamp on a klog/glog-formatted line, e.g. "I0726 10:55:37.123456".
+var klogTimestampRe = regexp.MustCompile(`^[IWEF](\d{2})(\d{2}) (\d{2}:\d{2}:\d{2}\.\d+)`)
+
+// sinceFlag returns the `--since` flag to append to a shell command for the given runtime/source
+// kind, or "" if this filter doesn't constrain time or the kind isn't one we know how to filter
+// at the command level (in which case keepLine still applies Since in-process, best effort).
+func (f *LogFilter) sinceFlag(kind string) string {
+	if f == nil || f.Since <= 0 {
+		return ""
+	}
+	switch kind {
+	case "journald":
+		return fmt.Sprintf(" --since=-%s", f.Since)
+	case "crictl", "docker":
+		return fmt.Sprintf(" --since=%s", f.Since)
+	default:
+		return ""
+	}
+}
+
+// keepLine reports whether line passes this filter's Grep, MinSeverity and (best-effort)
+// Since/Until constraints. sinceFlag/SinceSeconds also push Since down to the source command
+// where it's supported, as an optimization to avoid shipping lines the caller will only
+// immediately drop - but keepLine re-checks it here too, so a command whose source doesn't
+// support filtering (or composes its pipeline in a way sinceFlag can't safely append to) still
+// gets correct results rather than silently unfiltered ones.
+func (f *LogFilter) keepLine(component, line string) bool {
+	if f == nil {
+		return true
+	}
+	if !f.passesGrepSinceUntil(line) {
+		return false
+	}
+	if f.MinSeverity != "" {
+		p, ok := detector().Match(component, line)
+		if !ok || severityRank[p.Rule.Severity] < severityRank[f.MinSeverity] {
+			return false
+		}
+	}
+	return true
+}
+
+// passesGrepSinceUntil reports whether line passes Grep, Since and Until alone, leaving severity
+// to the caller - FindProblems already classifies severity via ProblemDetector.Match and would
+// otherwise double-filter. A line with no recognizable timestamp passes Since/Until rather than
+// being dropped, since most lines in a multi-line stack trace or log entry won't carry one.
+func (f *LogFilter) passesGrepSinceUntil(line string) bool {
+	if f == nil {
+		return true
+	}
+	if f.Grep != nil && !f.Grep.MatchString(line) {
+		return false
+	}
+	if f.Since > 0 {
+		if t, ok := parseLineTimestamp(line); ok && t.Before(time.Now().Add(-f.Since)) {
+			return false
+		}
+	}
+	if !f.Until.IsZero() {
+		if t, ok := parseLineTimestamp(line); ok && t.After(f.Until) {
+			return false
+		}
+	}
+	return true
+}
+
+// severityOK reports whether sev meets this filter's MinSeverity (no MinSeverity means anything
+// passes).
+func (f *LogFilter) severityOK(sev Severity) bool {
+	if f == nil || f.MinSeverity == "" {
+		return true
+	}
+	return severityRank[sev] >= severityRank[f.MinSeverity]
+}
+
+// parseLineTimestamp extracts a timestamp from the start of a log line, recognizing RFC3339
+// (used by most structured/JSON logs) and the klog/glog "I0726 10:55:37.123456" prefix used by
+// Kubernetes components' raw output. The current year is assumed for klog timestamps, since they
+// don't carry one.
+func parseLineTimestamp(line string) (time.Time, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, fields[0]); err == nil {
+		return t, true
+	}
+	if m := klogTimestampRe.FindStringSubmatch(line); m != nil {
+		ts := fmt.Sprintf("%d-%s-%sT%s", time.Now().Year(), m[1], m[2], m[3])
+		if t, err := time.Parse("2006-01-02T15:04:05.999999", ts); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// filterString applies f to an already-buffered blob of log output, dropping lines that don't pass.
+func filterString(f *LogFilter, component, s string) string {
+	if f == nil {
+		return s
+	}
+	var b strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		l := scanner.Text()
+		if f.keepLine(component, l) {
+			b.WriteString(l)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// filteredReadCloser wraps a ReadCloser, substituting a filtered Reader while still closing the
+// original source.
+type filteredReadCloser struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (f *filteredReadCloser) Close() error { return f.closer.Close() }
+
+// filterStream wraps rc so that only lines passing f reach the reader, streaming rather than
+// buffering so Follow can filter a live tail.
+func filterStream(f *LogFilter, component string, rc io.ReadCloser) io.ReadCloser {
+	if f == nil {
+		return rc
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		defer pw.Close()
+		scanner := bufio.NewScanner(rc)
+		for scanner.Scan() {
+			l := scanner.Text()
+			if f.keepLine(component, l) {
+				fmt.Fprintln(pw, l)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			klog.Warningf("filter: failed to read source for %s: %v", component, err)
+		}
+	}()
+	return &filteredReadCloser{Reader: pr, closer: rc}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2019 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestParseLineTimestamp(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		ok   bool
+	}{
+		{"rfc3339", "2021-01-02T15:04:05Z some message", true},
+		{"klog", "I0726 10:55:37.123456    1 server.go:1 starting", true},
+		{"no timestamp", "this line has no timestamp at all", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := parseLineTimestamp(tt.line)
+			if ok != tt.ok {
+				t.Errorf("parseLineTimestamp(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSinceFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		f    *LogFilter
+		kind string
+		want string
+	}{
+		{"nil filter", nil, "journald", ""},
+		{"no since", &LogFilter{}, "journald", ""},
+		{"journald", &LogFilter{Since: 10 * time.Minute}, "journald", " --since=-10m0s"},
+		{"docker", &LogFilter{Since: time.Hour}, "docker", " --since=1h0m0s"},
+		{"crictl", &LogFilter{Since: time.Hour}, "crictl", " --since=1h0m0s"},
+		{"unknown kind", &LogFilter{Since: time.Hour}, "unknown", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f.sinceFlag(tt.kind); got != tt.want {
+				t.Errorf("sinceFlag(%q) = %q, want %q", tt.kind, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepLineSince(t *testing.T) {
+	old := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	recent := time.Now().Format(time.RFC3339)
+	f := &LogFilter{Since: time.Minute}
+
+	if f.keepLine("kubelet", old+" too old") {
+		t.Errorf("expected line older than Since to be dropped")
+	}
+	if !f.keepLine("kubelet", recent+" recent enough") {
+		t.Errorf("expected line within Since to be kept")
+	}
+}
+
+func TestKeepLineGrep(t *testing.T) {
+	f := &LogFilter{Grep: regexp.MustCompile("boom")}
+	if f.keepLine("kubelet", "everything is fine") {
+		t.Errorf("expected non-matching line to be dropped by Grep")
+	}
+	if !f.keepLine("kubelet", "boom, it exploded") {
+		t.Errorf("expected matching line to pass Grep")
+	}
+}
+
+func TestKeepLineNilFilter(t *testing.T) {
+	var f *LogFilter
+	if !f.keepLine("kubelet", "anything goes") {
+		t.Errorf("nil filter should keep every line")
+	}
+}
+
+func TestSeverityOK(t *testing.T) {
+	f := &LogFilter{MinSeverity: SeverityWarn}
+	if f.severityOK(SeverityInfo) {
+		t.Errorf("info should not satisfy a warn MinSeverity")
+	}
+	if !f.severityOK(SeverityError) {
+		t.Errorf("error should satisfy a warn MinSeverity")
+	}
+	if !(*LogFilter)(nil).severityOK(SeverityInfo) {
+		t.Errorf("nil filter should accept any severity")
+	}
+}
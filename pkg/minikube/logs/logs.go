@@ -24,9 +24,9 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"regexp"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"k8s.io/klog/v2"
@@ -41,38 +41,6 @@ import (
 	"k8s.io/minikube/pkg/minikube/style"
 )
 
-// rootCauses are regular expressions that match known failures
-var rootCauses = []string{
-	`^error: `,
-	`eviction manager: pods.* evicted`,
-	`unknown flag: --`,
-	`forbidden.*no providers available`,
-	`eviction manager:.*evicted`,
-	`tls: bad certificate`,
-	`kubelet.*no API client`,
-	`kubelet.*No api server`,
-	`STDIN.*127.0.0.1:8080`,
-	`failed to create listener`,
-	`address already in use`,
-	`unable to evict any pods`,
-	`eviction manager: unexpected error`,
-	`Resetting AnonymousAuth to false`,
-	`Unable to register node.*forbidden`,
-	`Failed to initialize CSINodeInfo.*forbidden`,
-	`Failed to admit pod`,
-	`failed to "StartContainer"`,
-	`Failed to start ContainerManager`,
-	`kubelet.*forbidden.*cannot \w+ resource`,
-	`leases.*forbidden.*cannot \w+ resource`,
-	`failed to start daemon`,
-}
-
-// rootCauseRe combines rootCauses into a single regex
-var rootCauseRe = regexp.MustCompile(strings.Join(rootCauses, "|"))
-
-// ignoreCauseRe is a regular expression that matches spurious errors to not surface
-var ignoreCauseRe = regexp.MustCompile("error: no objects passed to apply")
-
 // importantPods are a list of pods to retrieve logs for, in addition to the bootstrapper logs.
 var importantPods = []string{
 	"kube-apiserver",
@@ -94,31 +62,66 @@ type logRunner interface {
 const lookBackwardsCount = 400
 
 // Follow follows logs from multiple files in tail(1) format
-func Follow(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, cr logRunner, logOutput io.Writer) error {
+func Follow(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, cr logRunner, logOutput io.Writer, filter *LogFilter) error {
+	pods := recordedPods(cfg)
+	apiSources, apiOK := apiPodLogs(cfg, pods, 0, true, false, filter)
+
+	cmds := nonPodLogCommands(r, bs, cfg, 0, true, filter)
+	if !apiOK {
+		for k, v := range podLogCommands(r, pods, 0, true, filter) {
+			cmds[k] = v
+		}
+	}
+
 	cs := []string{}
-	for _, v := range logCommands(r, bs, cfg, 0, true) {
+	for _, v := range cmds {
 		cs = append(cs, v+" &")
 	}
 	cs = append(cs, "wait")
 
+	var wg sync.WaitGroup
+	if apiOK {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			streamAll(logOutput, apiSources)
+		}()
+	}
+
+	// The runtime commands' combined output is filtered as one stream with no component, so
+	// MinSeverity there only counts rules that have no Component filter.
+	cmdOutput := io.Writer(logOutput)
+	pr, pw := io.Pipe()
+	if filter != nil {
+		cmdOutput = pw
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rc := filterStream(filter, "", pr)
+			defer rc.Close()
+			if _, err := io.Copy(logOutput, rc); err != nil {
+				klog.Warningf("failed to copy filtered log output: %v", err)
+			}
+		}()
+	}
+
 	cmd := exec.Command("/bin/bash", "-c", strings.Join(cs, " "))
-	cmd.Stdout = logOutput
-	cmd.Stderr = logOutput
-	if _, err := cr.RunCmd(cmd); err != nil {
+	cmd.Stdout = cmdOutput
+	cmd.Stderr = cmdOutput
+	_, err := cr.RunCmd(cmd)
+	pw.Close()
+	wg.Wait()
+	if err != nil {
 		return errors.Wrapf(err, "log follow")
 	}
 	return nil
 }
 
-// IsProblem returns whether this line matches a known problem
-func IsProblem(line string) bool {
-	return rootCauseRe.MatchString(line) && !ignoreCauseRe.MatchString(line)
-}
-
 // FindProblems finds possible root causes among the logs
-func FindProblems(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, cr logRunner) map[string][]string {
-	pMap := map[string][]string{}
-	cmds := logCommands(r, bs, cfg, lookBackwardsCount, false)
+func FindProblems(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, cr logRunner, filter *LogFilter) map[string][]Problem {
+	pd := detector()
+	pMap := map[string][]Problem{}
+	cmds := logCommands(r, bs, cfg, lookBackwardsCount, false, filter)
 	for name := range cmds {
 		klog.Infof("Gathering logs for %s ...", name)
 		var b bytes.Buffer
@@ -131,13 +134,18 @@ func FindProblems(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.C
 			continue
 		}
 		scanner := bufio.NewScanner(&b)
-		problems := []string{}
+		problems := []Problem{}
 		for scanner.Scan() {
 			l := scanner.Text()
-			if IsProblem(l) {
-				klog.Warningf("Found %s problem: %s", name, l)
-				problems = append(problems, l)
+			if !filter.passesGrepSinceUntil(l) {
+				continue
 			}
+			p, ok := pd.Match(name, l)
+			if !ok || !filter.severityOK(p.Rule.Severity) {
+				continue
+			}
+			klog.Warningf("Found %s problem: %s", name, l)
+			problems = append(problems, p)
 		}
 		if err := scanner.Err(); err != nil {
 			klog.Warningf("failed to read output: %v", err)
@@ -149,31 +157,73 @@ func FindProblems(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.C
 	return pMap
 }
 
-// OutputProblems outputs discovered problems.
-func OutputProblems(problems map[string][]string, maxLines int, logOutput *os.File) {
+// OutputProblems outputs discovered problems, along with each rule's suggestion.
+func OutputProblems(problems map[string][]Problem, maxLines int, logOutput *os.File) {
 	out.SetErrFile(logOutput)
 	defer out.SetErrFile(os.Stderr)
 
-	for name, lines := range problems {
+	for name, ps := range problems {
 		out.FailureT("Problems detected in {{.name}}:", out.V{"name": name})
-		if len(lines) > maxLines {
-			lines = lines[len(lines)-maxLines:]
+		if len(ps) > maxLines {
+			ps = ps[len(ps)-maxLines:]
 		}
-		for _, l := range lines {
-			out.ErrT(style.LogEntry, l)
+		for _, p := range ps {
+			out.ErrT(style.LogEntry, p.Line)
+			if p.Rule.Suggestion != "" {
+				out.ErrT(style.Tip, p.Rule.Suggestion)
+			}
+		}
+	}
+}
+
+// OutputProblemsExplain outputs discovered problems along with the full rule metadata that
+// matched them, for `minikube logs --explain`.
+func OutputProblemsExplain(problems map[string][]Problem, maxLines int, logOutput *os.File) {
+	out.SetErrFile(logOutput)
+	defer out.SetErrFile(os.Stderr)
+
+	for name, ps := range problems {
+		out.FailureT("Problems detected in {{.name}}:", out.V{"name": name})
+		if len(ps) > maxLines {
+			ps = ps[len(ps)-maxLines:]
+		}
+		for _, p := range ps {
+			out.ErrT(style.LogEntry, p.Line)
+			out.ErrT(style.Tip, "[{{.name}}] severity={{.severity}} component={{.component}}", out.V{
+				"name":      p.Rule.Name,
+				"severity":  p.Rule.Severity,
+				"component": p.Rule.Component,
+			})
+			if p.Rule.Suggestion != "" {
+				out.ErrT(style.Tip, p.Rule.Suggestion)
+			}
+			if p.Rule.DocURL != "" {
+				out.ErrT(style.Tip, p.Rule.DocURL)
+			}
 		}
 	}
 }
 
 // Output displays logs from multiple sources in tail(1) format
-func Output(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, runner command.Runner, lines int, logOutput *os.File) {
-	cmds := logCommands(r, bs, cfg, lines, false)
+func Output(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, runner command.Runner, lines int, logOutput *os.File, filter *LogFilter) {
+	pods := recordedPods(cfg)
+	apiSources, apiOK := apiPodLogs(cfg, pods, lines, false, false, filter)
+
+	cmds := nonPodLogCommands(r, bs, cfg, lines, false, filter)
 	cmds["kernel"] = "uptime && uname -a && grep PRETTY /etc/os-release"
+	if !apiOK {
+		for k, v := range podLogCommands(r, pods, lines, false, filter) {
+			cmds[k] = v
+		}
+	}
 
 	names := []string{}
 	for k := range cmds {
 		names = append(names, k)
 	}
+	for k := range apiSources {
+		names = append(names, k)
+	}
 
 	out.SetOutFile(logOutput)
 	defer out.SetOutFile(os.Stdout)
@@ -186,6 +236,16 @@ func Output(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.Cluster
 			out.Styled(style.None, "")
 		}
 		out.Styled(style.None, "==> {{.name}} <==", out.V{"name": name})
+		if rc, ok := apiSources[name]; ok {
+			b, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				out.Styled(style.None, fmt.Sprintf("failed to read apiserver logs for %s: %v", name, err))
+				continue
+			}
+			out.Styled(style.None, string(b))
+			continue
+		}
 		var b bytes.Buffer
 		c := exec.Command("/bin/bash", "-c", cmds[name])
 		c.Stdout = &b
@@ -202,10 +262,45 @@ func Output(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.Cluster
 		if err := scanner.Err(); err != nil {
 			l += fmt.Sprintf("failed to read output: %v", err)
 		}
-		out.Styled(style.None, l)
+		out.Styled(style.None, filterString(filter, name, l))
 	}
 }
 
+// OutputPrevious displays the last crashed container's logs for each recorded pod, fetched via
+// the apiserver's Previous log option. There is no runtime-command equivalent: ContainerLogCmd
+// only composes a command for the currently running container.
+func OutputPrevious(cfg config.ClusterConfig, lines int, logOutput *os.File, filter *LogFilter) error {
+	pods := recordedPods(cfg)
+	apiSources, ok := apiPodLogs(cfg, pods, lines, false, true, filter)
+	if !ok {
+		return fmt.Errorf("apiserver is not reachable, cannot fetch previous container logs")
+	}
+
+	out.SetOutFile(logOutput)
+	defer out.SetOutFile(os.Stdout)
+
+	names := []string{}
+	for k := range apiSources {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if i > 0 {
+			out.Styled(style.None, "")
+		}
+		out.Styled(style.None, "==> {{.name}} (previous) <==", out.V{"name": name})
+		rc := apiSources[name]
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			out.Styled(style.None, fmt.Sprintf("failed to read previous logs for %s: %v", name, err))
+			continue
+		}
+		out.Styled(style.None, string(b))
+	}
+	return nil
+}
+
 // OutputAudit displays the audit logs.
 func OutputAudit(lines int) error {
 	out.Styled(style.None, "")
@@ -268,11 +363,32 @@ func OutputOffline(lines int, logOutput *os.File) {
 }
 
 // logCommands returns a list of commands that would be run to receive the anticipated logs
-func logCommands(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, length int, follow bool) map[string]string {
+func logCommands(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, length int, follow bool, filter *LogFilter) map[string]string {
+	cmds := nonPodLogCommands(r, bs, cfg, length, follow, filter)
+	for k, v := range podLogCommands(r, recordedPods(cfg), length, follow, filter) {
+		cmds[k] = v
+	}
+	return cmds
+}
+
+// nonPodLogCommands returns the bootstrapper, runtime and container-status commands that have no
+// apiserver equivalent, and so are always gathered by shelling out through the runner. The
+// bootstrapper's own commands are journald-based (kubelet, the runtime service unit, ...), so
+// filter's Since is applied to them as a journalctl flag.
+func nonPodLogCommands(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, length int, follow bool, filter *LogFilter) map[string]string {
 	cmds := bs.LogCommands(cfg, bootstrapper.LogOptions{Lines: length, Follow: follow})
-	pods := importantPods
-	addonPods := enabledAddonPods(cfg)
-	pods = append(pods, addonPods...)
+	for k, v := range cmds {
+		cmds[k] = v + filter.sinceFlag("journald")
+	}
+	cmds[r.Name()] = r.SystemLogCmd(length) + filter.sinceFlag("journald")
+	cmds["container status"] = cruntime.ContainerStatusCommand()
+	return cmds
+}
+
+// podLogCommands returns the crictl/docker log commands for each container backing pods, for use
+// when the apiserver can't be reached to fetch them directly.
+func podLogCommands(r cruntime.Manager, pods []string, length int, follow bool, filter *LogFilter) map[string]string {
+	cmds := map[string]string{}
 	for _, pod := range pods {
 		ids, err := r.ListContainers(cruntime.ListContainersOptions{Name: pod})
 		if err != nil {
@@ -286,15 +402,19 @@ func logCommands(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.Cl
 		}
 		for _, i := range ids {
 			key := fmt.Sprintf("%s [%s]", pod, i)
-			cmds[key] = r.ContainerLogCmd(i, length, follow)
+			cmds[key] = r.ContainerLogCmd(i, length, follow) + filter.sinceFlag(runtimeKind(r))
 		}
 	}
-	cmds[r.Name()] = r.SystemLogCmd(length)
-	cmds["container status"] = cruntime.ContainerStatusCommand()
-
 	return cmds
 }
 
+// recordedPods returns importantPods plus the pods for any enabled addon minikube occasionally
+// gets issue reports about.
+func recordedPods(cfg config.ClusterConfig) []string {
+	pods := append([]string{}, importantPods...)
+	return append(pods, enabledAddonPods(cfg)...)
+}
+
 // enabledAddonPods returns the pod names for enabled addons
 // this does not currently include all addons, mostly just addons that we occasionally get users reporting issues with
 func enabledAddonPods(cfg config.ClusterConfig) []string {
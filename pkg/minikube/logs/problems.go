@@ -0,0 +1,201 @@
+/*
+Copyright 2019 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/localpath"
+	"sigs.k8s.io/yaml"
+)
+
+//go:embed problem-rules.yaml
+var embeddedProblemRules []byte
+
+// problemRulesFile is the name of the user-supplied override file, relative to $MINIKUBE_HOME.
+const problemRulesFile = "problem-rules.yaml"
+
+// Severity is how serious a matched problem is.
+type Severity string
+
+// Severity levels, ordered from least to most serious.
+const (
+	SeverityInfo  Severity = "info"
+	SeverityWarn  Severity = "warn"
+	SeverityError Severity = "error"
+)
+
+// Rule describes a single known failure pattern.
+type Rule struct {
+	Name       string   `json:"name"`
+	Pattern    string   `json:"pattern"`
+	Severity   Severity `json:"severity"`
+	Component  string   `json:"component,omitempty"`
+	Suggestion string   `json:"suggestion,omitempty"`
+	DocURL     string   `json:"doc_url,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// Problem is a single rule match against a log line.
+type Problem struct {
+	Line string `json:"line"`
+	Rule Rule   `json:"rule"`
+}
+
+// ruleSet is the on-disk (YAML) shape of a rules file.
+type ruleSet struct {
+	Rules  []Rule   `json:"rules"`
+	Ignore []string `json:"ignore"`
+}
+
+// ProblemDetector matches log lines against a configurable set of known-failure rules.
+type ProblemDetector struct {
+	rules    []Rule
+	ignoreRe *regexp.Regexp
+}
+
+// defaultDetector is lazily built from the embedded rules plus any user overrides.
+var defaultDetector *ProblemDetector
+
+// NewProblemDetector builds a ProblemDetector from the embedded ruleset, merged with any
+// user-supplied overrides at $MINIKUBE_HOME/problem-rules.yaml. A user rule with the same
+// name as an embedded one replaces it; any other user rule is appended.
+func NewProblemDetector() (*ProblemDetector, error) {
+	rs, err := loadRuleSet(embeddedProblemRules)
+	if err != nil {
+		return nil, fmt.Errorf("parsing embedded problem-rules.yaml: %v", err)
+	}
+
+	overridePath := filepath.Join(localpath.MiniPath(), problemRulesFile)
+	if b, err := os.ReadFile(overridePath); err == nil {
+		userRS, err := loadRuleSet(b)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %v", overridePath, err)
+		}
+		rs.Rules = mergeRules(rs.Rules, userRS.Rules)
+		rs.Ignore = append(rs.Ignore, userRS.Ignore...)
+	} else if !os.IsNotExist(err) {
+		klog.Warningf("failed to read %s: %v", overridePath, err)
+	}
+
+	pd := &ProblemDetector{}
+	for i := range rs.Rules {
+		r := rs.Rules[i]
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("compiling rule %q: %v", r.Name, err)
+		}
+		r.re = re
+		pd.rules = append(pd.rules, r)
+	}
+	if len(rs.Ignore) > 0 {
+		pd.ignoreRe = regexp.MustCompile(strings.Join(rs.Ignore, "|"))
+	}
+	return pd, nil
+}
+
+// loadRuleSet parses a YAML document into a ruleSet.
+func loadRuleSet(b []byte) (ruleSet, error) {
+	var rs ruleSet
+	if err := yaml.Unmarshal(b, &rs); err != nil {
+		return rs, err
+	}
+	return rs, nil
+}
+
+// mergeRules overlays user rules onto the base rules, replacing any with a matching name.
+func mergeRules(base, user []Rule) []Rule {
+	out := append([]Rule{}, base...)
+	for _, u := range user {
+		replaced := false
+		for i, r := range out {
+			if r.Name == u.Name {
+				out[i] = u
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// Match returns the first rule that matches line for the given component, if any.
+// component is the log source name (e.g. "kubelet", "etcd"); rules with no Component
+// filter apply to every source.
+func (pd *ProblemDetector) Match(component, line string) (Problem, bool) {
+	if pd.ignoreRe != nil && pd.ignoreRe.MatchString(line) {
+		return Problem{}, false
+	}
+	for _, r := range pd.rules {
+		if r.Component != "" && !strings.Contains(component, r.Component) {
+			continue
+		}
+		if r.re.MatchString(line) {
+			return Problem{Line: line, Rule: r}, true
+		}
+	}
+	return Problem{}, false
+}
+
+// MatchAny returns the first rule that matches line, ignoring any Component filter - for callers
+// that don't know which source a line came from.
+func (pd *ProblemDetector) MatchAny(line string) (Problem, bool) {
+	if pd.ignoreRe != nil && pd.ignoreRe.MatchString(line) {
+		return Problem{}, false
+	}
+	for _, r := range pd.rules {
+		if r.re.MatchString(line) {
+			return Problem{Line: line, Rule: r}, true
+		}
+	}
+	return Problem{}, false
+}
+
+// defaultDetectorOnce guards building defaultDetector, since detector() is now called
+// concurrently (Follow's apiserver streams, filterStream, FindProblems all run it from multiple
+// goroutines).
+var defaultDetectorOnce sync.Once
+
+// detector returns the process-wide default ProblemDetector, building it on first use.
+func detector() *ProblemDetector {
+	defaultDetectorOnce.Do(func() {
+		pd, err := NewProblemDetector()
+		if err != nil {
+			klog.Errorf("failed to build problem detector, falling back to no rules: %v", err)
+			pd = &ProblemDetector{}
+		}
+		defaultDetector = pd
+	})
+	return defaultDetector
+}
+
+// IsProblem returns whether this line matches a known problem, regardless of component.
+func IsProblem(line string) bool {
+	_, ok := detector().MatchAny(line)
+	return ok
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2019 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestMergeRules(t *testing.T) {
+	base := []Rule{
+		{Name: "a", Pattern: "base-a"},
+		{Name: "b", Pattern: "base-b"},
+	}
+	user := []Rule{
+		{Name: "b", Pattern: "user-b"}, // overrides base "b"
+		{Name: "c", Pattern: "user-c"}, // appended
+	}
+
+	got := mergeRules(base, user)
+	if len(got) != 3 {
+		t.Fatalf("mergeRules returned %d rules, want 3", len(got))
+	}
+
+	byName := map[string]Rule{}
+	for _, r := range got {
+		byName[r.Name] = r
+	}
+	if byName["a"].Pattern != "base-a" {
+		t.Errorf("rule a: got pattern %q, want unchanged base-a", byName["a"].Pattern)
+	}
+	if byName["b"].Pattern != "user-b" {
+		t.Errorf("rule b: got pattern %q, want user override user-b", byName["b"].Pattern)
+	}
+	if byName["c"].Pattern != "user-c" {
+		t.Errorf("rule c: got pattern %q, want appended user-c", byName["c"].Pattern)
+	}
+}
+
+func TestProblemDetectorMatch(t *testing.T) {
+	pd := &ProblemDetector{
+		rules: []Rule{
+			{Name: "etcd-only", Pattern: "connection refused", Component: "etcd", Severity: SeverityError, re: regexp.MustCompile("connection refused")},
+			{Name: "any-component", Pattern: "out of memory", Severity: SeverityError, re: regexp.MustCompile("out of memory")},
+		},
+	}
+
+	if _, ok := pd.Match("kubelet", "connection refused"); ok {
+		t.Errorf("component-scoped rule should not match a different component")
+	}
+	p, ok := pd.Match("etcd", "connection refused")
+	if !ok || p.Rule.Name != "etcd-only" {
+		t.Errorf("expected etcd-only rule to match etcd component, got %+v, %v", p, ok)
+	}
+	if _, ok := pd.Match("kubelet", "out of memory"); !ok {
+		t.Errorf("rule with no Component filter should match any component")
+	}
+}
+
+func TestProblemDetectorMatchAny(t *testing.T) {
+	pd := &ProblemDetector{
+		rules: []Rule{
+			{Name: "etcd-only", Pattern: "connection refused", Component: "etcd", Severity: SeverityError, re: regexp.MustCompile("connection refused")},
+		},
+	}
+
+	if _, ok := pd.MatchAny("connection refused"); !ok {
+		t.Errorf("MatchAny should ignore Component and still match")
+	}
+	if _, ok := pd.MatchAny("nothing interesting here"); ok {
+		t.Errorf("MatchAny should not match an unrelated line")
+	}
+}
+
+func TestProblemDetectorIgnore(t *testing.T) {
+	pd := &ProblemDetector{
+		rules: []Rule{
+			{Name: "any-component", Pattern: "out of memory", Severity: SeverityError, re: regexp.MustCompile("out of memory")},
+		},
+		ignoreRe: regexp.MustCompile("test harness"),
+	}
+
+	if _, ok := pd.Match("kubelet", "out of memory (test harness)"); ok {
+		t.Errorf("ignoreRe should suppress an otherwise-matching line")
+	}
+	if _, ok := pd.MatchAny("out of memory (test harness)"); ok {
+		t.Errorf("ignoreRe should suppress an otherwise-matching line in MatchAny too")
+	}
+}
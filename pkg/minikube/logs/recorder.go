@@ -0,0 +1,287 @@
+/*
+Copyright 2019 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/bootstrapper"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+)
+
+// systemLogKey is the captures-map key used for the container runtime's own system log, which
+// isn't keyed by a container ID like pod captures are.
+const systemLogKey = "__system__"
+
+// recorderPollInterval is how often the Recorder checks for new or restarted containers.
+const recorderPollInterval = 5 * time.Second
+
+// manifestEntry describes a single captured log file.
+type manifestEntry struct {
+	Pod         string `json:"pod"`
+	ContainerID string `json:"container_id"`
+	Node        string `json:"node"`
+	File        string `json:"file"`
+	StartedAt   string `json:"started_at"`
+}
+
+// capture tracks the state of a single in-flight tail.
+type capture struct {
+	cancel context.CancelFunc
+}
+
+// Recorder streams per-container logs to disk for the lifetime of a cluster, so that
+// transient crash output survives long enough for `minikube logs` to find it later.
+type Recorder struct {
+	r   cruntime.Manager
+	bs  bootstrapper.Bootstrapper
+	cfg config.ClusterConfig
+	cr  logRunner
+	dir string
+
+	mu       sync.Mutex
+	captures map[string]*capture // keyed by containerID
+	manifest []manifestEntry
+	stopCh   chan struct{}
+	stopped  bool
+	wg       sync.WaitGroup
+}
+
+// NewRecorder creates a Recorder that writes captured logs under baseDir/<profile>/<timestamp>.
+func NewRecorder(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, cr logRunner, baseDir string) *Recorder {
+	dir := filepath.Join(baseDir, cfg.Name, time.Now().Format("20060102-150405"))
+	return &Recorder{
+		r:        r,
+		bs:       bs,
+		cfg:      cfg,
+		cr:       cr,
+		dir:      dir,
+		captures: map[string]*capture{},
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start creates the recording directory and begins polling for containers in the background.
+func (rec *Recorder) Start() error {
+	if err := os.MkdirAll(rec.dir, 0o755); err != nil {
+		return fmt.Errorf("creating log recording dir: %v", err)
+	}
+	klog.Infof("log recorder: capturing to %s", rec.dir)
+
+	rec.attachSystemLog()
+
+	rec.wg.Add(1)
+	go func() {
+		defer rec.wg.Done()
+		t := time.NewTicker(recorderPollInterval)
+		defer t.Stop()
+		for {
+			rec.poll()
+			select {
+			case <-rec.stopCh:
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop gracefully ends all in-flight captures and flushes the manifest. Every capture is
+// cancelled before we wait on the WaitGroup: each capture's tail goroutine only returns once its
+// RunCmd unblocks, which for a `--follow` tail never happens on its own, so waiting first would
+// hang forever.
+func (rec *Recorder) Stop() {
+	rec.mu.Lock()
+	if rec.stopped {
+		rec.mu.Unlock()
+		return
+	}
+	rec.stopped = true
+	close(rec.stopCh)
+	for _, c := range rec.captures {
+		rec.closeCapture(c)
+	}
+	rec.mu.Unlock()
+
+	rec.wg.Wait()
+
+	if err := rec.writeManifest(); err != nil {
+		klog.Warningf("log recorder: failed to write manifest: %v", err)
+	}
+}
+
+// poll lists containers for every recorded pod and attaches tails to any that are new.
+func (rec *Recorder) poll() {
+	for _, pod := range recordedPods(rec.cfg) {
+		ids, err := rec.r.ListContainers(cruntime.ListContainersOptions{Name: pod})
+		if err != nil {
+			klog.Warningf("log recorder: failed to list containers for %q: %v", pod, err)
+			continue
+		}
+		for _, id := range ids {
+			rec.mu.Lock()
+			_, attached := rec.captures[id]
+			rec.mu.Unlock()
+			if attached {
+				continue
+			}
+			rec.attach(pod, id)
+		}
+	}
+}
+
+// attach begins streaming a single container's logs to its own file, via rec.cr so the command
+// runs on the minikube node (over SSH for VM drivers) rather than on the host running the CLI.
+func (rec *Recorder) attach(pod, id string) {
+	short := id
+	if len(short) > 12 {
+		short = short[:12]
+	}
+	name := fmt.Sprintf("%s_%s.log", sanitize(pod), short)
+	path := filepath.Join(rec.dir, name)
+
+	f, err := os.Create(path)
+	if err != nil {
+		klog.Warningf("log recorder: failed to create %s: %v", path, err)
+		return
+	}
+
+	cmdline := rec.r.ContainerLogCmd(id, 0, true)
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", cmdline)
+	cmd.Stdout = f
+	cmd.Stderr = f
+
+	klog.Infof("log recorder: attaching to %s [%s] -> %s", pod, short, path)
+
+	rec.mu.Lock()
+	if rec.stopped {
+		rec.mu.Unlock()
+		cancel()
+		f.Close()
+		return
+	}
+	rec.captures[id] = &capture{cancel: cancel}
+	rec.manifest = append(rec.manifest, manifestEntry{
+		Pod:         pod,
+		ContainerID: id,
+		Node:        rec.cfg.Name,
+		File:        name,
+		StartedAt:   time.Now().Format(time.RFC3339),
+	})
+	// wg.Add must happen in the same critical section as the stopped check: if it happened
+	// after unlocking, Stop() could already have swept rec.captures and returned from
+	// rec.wg.Wait() before this Add runs, and this tail's --follow would then never be
+	// cancelled or waited on.
+	rec.wg.Add(1)
+	rec.mu.Unlock()
+
+	// Reap the tail once it ends - either because the container restarted out from under it,
+	// or because Stop() cancelled it - so the pod can be reattached (as a new container ID) on
+	// the next poll, without leaking the old file.
+	go func() {
+		defer rec.wg.Done()
+		defer f.Close()
+		if _, err := rec.cr.RunCmd(cmd); err != nil {
+			klog.Infof("log recorder: tail for %s [%s] ended: %v", pod, short, err)
+		}
+		rec.mu.Lock()
+		delete(rec.captures, id)
+		rec.mu.Unlock()
+	}()
+}
+
+// attachSystemLog begins streaming the container runtime's own system log (e.g. the docker or
+// containerd systemd unit), alongside the per-pod container captures.
+func (rec *Recorder) attachSystemLog() {
+	name := fmt.Sprintf("system_%s.log", sanitize(rec.r.Name()))
+	path := filepath.Join(rec.dir, name)
+
+	f, err := os.Create(path)
+	if err != nil {
+		klog.Warningf("log recorder: failed to create %s: %v", path, err)
+		return
+	}
+
+	cmdline := rec.r.SystemLogCmd(0) + " -f"
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", cmdline)
+	cmd.Stdout = f
+	cmd.Stderr = f
+
+	klog.Infof("log recorder: attaching to %s system log -> %s", rec.r.Name(), path)
+
+	rec.mu.Lock()
+	if rec.stopped {
+		rec.mu.Unlock()
+		cancel()
+		f.Close()
+		return
+	}
+	rec.captures[systemLogKey] = &capture{cancel: cancel}
+	rec.manifest = append(rec.manifest, manifestEntry{
+		Pod:         "system",
+		ContainerID: rec.r.Name(),
+		Node:        rec.cfg.Name,
+		File:        name,
+		StartedAt:   time.Now().Format(time.RFC3339),
+	})
+	rec.wg.Add(1)
+	rec.mu.Unlock()
+
+	go func() {
+		defer rec.wg.Done()
+		defer f.Close()
+		if _, err := rec.cr.RunCmd(cmd); err != nil {
+			klog.Infof("log recorder: system log tail ended: %v", err)
+		}
+		rec.mu.Lock()
+		delete(rec.captures, systemLogKey)
+		rec.mu.Unlock()
+	}()
+}
+
+// closeCapture cancels an in-flight tail; its goroutine (see attach/attachSystemLog) notices,
+// lets RunCmd unblock, and removes itself from rec.captures.
+func (rec *Recorder) closeCapture(c *capture) {
+	c.cancel()
+}
+
+// writeManifest writes manifest.json describing every captured file.
+func (rec *Recorder) writeManifest() error {
+	b, err := json.MarshalIndent(rec.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(rec.dir, "manifest.json"), b, 0o644)
+}
+
+// sanitize makes a pod name safe to use as part of a filename.
+func sanitize(s string) string {
+	return strings.NewReplacer("/", "_", " ", "_").Replace(s)
+}